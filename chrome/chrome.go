@@ -8,10 +8,13 @@ import (
 	"os/exec"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
 	gover "github.com/mcuadros/go-version"
 	log "github.com/sirupsen/logrus"
 )
@@ -24,6 +27,11 @@ type Chrome struct {
 	UserAgent        string
 	Argvs            []string
 	ScreenshotPath   string
+
+	allocCtx      context.Context
+	allocCancel   context.CancelFunc
+	browserCtx    context.Context
+	browserCancel context.CancelFunc
 }
 
 func (chrome *Chrome) setLoggerStatus(status bool) {
@@ -32,18 +40,92 @@ func (chrome *Chrome) setLoggerStatus(status bool) {
 	}
 }
 
-func (chrome *Chrome) Setup() {
-	chrome.chromeLocator()
+// Setup locates a Chrome binary and starts a single persistent browser
+// instance over the DevTools protocol. Every subsequent Screenshot call
+// reuses this browser, each in its own CDP tab, instead of forking a new
+// process per target.
+func (chrome *Chrome) Setup() error {
+	if err := chrome.chromeLocator(); err != nil {
+		return err
+	}
+
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.IgnoreCertErrors,
+		chromedp.WindowSize(chrome.resolutionDims()),
+	)
+
+	if chrome.Path != "" {
+		opts = append(opts, chromedp.ExecPath(chrome.Path))
+	}
+
+	if chrome.UserAgent != "" {
+		opts = append(opts, chromedp.UserAgent(chrome.UserAgent))
+	}
+
+	for _, argv := range chrome.Argvs {
+		flag := strings.TrimPrefix(argv, "--")
+		name, value, hasValue := strings.Cut(flag, "=")
+		if hasValue {
+			opts = append(opts, chromedp.Flag(name, value))
+		} else {
+			opts = append(opts, chromedp.Flag(name, true))
+		}
+	}
+
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	browserCtx, browserCancel := chromedp.NewContext(allocCtx)
+
+	if err := chromedp.Run(browserCtx); err != nil {
+		browserCancel()
+		allocCancel()
+		return errors.Wrap(err, "failed to start Chrome")
+	}
+
+	chrome.allocCtx = allocCtx
+	chrome.allocCancel = allocCancel
+	chrome.browserCtx = browserCtx
+	chrome.browserCancel = browserCancel
+
+	return nil
+}
+
+// Close tears down the persistent browser instance started by Setup.
+func (chrome *Chrome) Close() {
+	if chrome.browserCancel != nil {
+		chrome.browserCancel()
+	}
+	if chrome.allocCancel != nil {
+		chrome.allocCancel()
+	}
+}
+
+func (chrome *Chrome) resolutionDims() (int, int) {
+	parts := strings.SplitN(chrome.Resolution, "x", 2)
+	if len(parts) != 2 {
+		return 1024, 768
+	}
+
+	width, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 1024, 768
+	}
+
+	height, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 1024, 768
+	}
+
+	return width, height
 }
 
-func (chrome *Chrome) chromeLocator() {
+func (chrome *Chrome) chromeLocator() error {
 	if _, err := os.Stat(chrome.Path); os.IsNotExist(err) {
 		log.WithFields(log.Fields{"user-path": chrome.Path, "error": err}).
 			Debug("Chrome path not set or invalid. Performing search")
 	} else {
 
 		log.Debug("Chrome path exists, skipping search and version check")
-		return
+		return nil
 	}
 
 	paths := []string{
@@ -73,10 +155,12 @@ func (chrome *Chrome) chromeLocator() {
 	}
 
 	if chrome.Path == "" {
-		log.Fatal("Unable to locate a valid installation of Chrome to use. gowitness needs at least Chrome/" +
+		return errors.New("unable to locate a valid installation of Chrome to use. gowitness needs at least Chrome/" +
 			"Chrome Canary v60+. Either install Google Chrome or try specifying a valid location with " +
 			"the --chrome-path flag")
 	}
+
+	return nil
 }
 
 func (chrome *Chrome) checkVersion(lowestVersion string) bool {
@@ -123,89 +207,78 @@ func (chrome *Chrome) SetScreenshotPath(p string) error {
 	return nil
 }
 
-func (chrome *Chrome) ScreenshotURL(targetURL *url.URL, destination string) {
-
-	log.WithFields(log.Fields{"url": targetURL, "full-destination": destination}).
-		Debug("Full path to screenshot save using Chrome")
-
-	var chromeArguments = []string{
-		"--headless", "--disable-gpu", "--hide-scrollbars",
-		"--disable-crash-reporter", "--no-sandbox",
-		// "--disable-software-rasterizer", "--disable-dev-shm-usage",
-		"--user-agent=" + chrome.UserAgent,
-		"--window-size=" + chrome.Resolution, "--screenshot=" + destination,
-		"--virtual-time-budget=" + strconv.Itoa(chrome.ChromeTimeBudget*6000),
-	}
-
-	if len(chrome.Argvs) > 0 {
-		for _, a := range chrome.Argvs {
-			chromeArguments = append(chromeArguments, a)
-		}
-	}
-
-	log.Info(chromeArguments)
-
-	if os.Geteuid() == 0 {
-		log.WithField("euid", os.Geteuid()).Debug("Running as root, adding --no-sandbox")
-		chromeArguments = append(chromeArguments, "--no-sandbox")
+// Screenshot navigates to targetURL in a fresh tab of the shared browser
+// context and returns a PNG-encoded screenshot. Callers own the returned
+// bytes and decide whether to write them to disk or hand them to the
+// results pipeline.
+func (chrome *Chrome) Screenshot(ctx context.Context, targetURL string) ([]byte, error) {
+	if chrome.browserCtx == nil {
+		return nil, errors.New("chrome: Setup must be called before Screenshot")
 	}
 
-	if targetURL.Scheme == "https" {
-
-		originalPath := targetURL.Path
-		proxy := forwardingProxy{targetURL: targetURL}
-
-		time.Sleep(500 * time.Millisecond)
-
-		if err := proxy.start(); err != nil {
+	tabCtx, cancel := chromedp.NewContext(chrome.browserCtx)
+	defer cancel()
 
-			log.WithField("error", err).Warning("Failed to start proxy for HTTPS request")
-			return
+	timeout := time.Duration(chrome.ChromeTimeout) * time.Second
+	tabCtx, timeoutCancel := context.WithTimeout(tabCtx, timeout)
+	defer timeoutCancel()
+
+	// tabCtx must derive from the shared browserCtx, not from ctx
+	// directly, so a caller-supplied deadline/cancellation is relayed by
+	// tearing tabCtx down when ctx is done instead.
+	relayDone := make(chan struct{})
+	defer close(relayDone)
+	go func() {
+		select {
+		case <-ctx.Done():
+			timeoutCancel()
+		case <-relayDone:
 		}
+	}()
 
-		proxyURL, _ := url.Parse("http://localhost:" + strconv.Itoa(proxy.port) + "/")
-		proxyURL.Path = originalPath
-
-		chromeArguments = append(chromeArguments, "--allow-insecure-localhost")
-
-		chromeArguments = append(chromeArguments, proxyURL.String())
-
-		defer proxy.stop()
-
-	} else {
-
-		chromeArguments = append(chromeArguments, targetURL.String())
-	}
-
-	log.WithFields(log.Fields{"arguments": chromeArguments}).Debug("Google Chrome arguments")
+	log.WithField("url", targetURL).Debug("Taking screenshot over CDP")
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(chrome.ChromeTimeout)*time.Second)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, chrome.Path, chromeArguments...)
+	startTime := time.Now()
 
-	log.WithFields(log.Fields{"url": targetURL, "destination": destination}).Info("Taking screenshot")
+	var buf []byte
+	err := chromedp.Run(tabCtx,
+		chromedp.Navigate(targetURL),
+		chromedp.Sleep(time.Duration(chrome.ChromeTimeBudget)*time.Second),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			buf, _, err = page.CaptureScreenshot().WithFormat(page.CaptureScreenshotFormatPng).Do(ctx)
+			return err
+		}),
+	)
+	if err != nil {
+		if ctx.Err() != nil || tabCtx.Err() == context.DeadlineExceeded {
+			return nil, errors.Wrap(err, "timeout reached while waiting for screenshot to finish")
+		}
 
-	startTime := time.Now()
-	if err := cmd.Start(); err != nil {
-		log.Fatal(err)
+		return nil, errors.Wrap(err, "screenshot failed")
 	}
 
-	if err := cmd.Wait(); err != nil {
+	log.WithFields(log.Fields{"url": targetURL, "duration": time.Since(startTime)}).Info("Screenshot taken")
 
-		if ctx.Err() == context.DeadlineExceeded {
-			log.WithFields(log.Fields{"url": targetURL, "destination": destination, "err": err}).
-				Error("Timeout reached while waiting for screenshot to finish")
-			return
-		}
+	return buf, nil
+}
 
+// ScreenshotURL is a convenience wrapper around Screenshot that writes the
+// captured image straight to destination, kept for callers that only ever
+// want a file on disk.
+func (chrome *Chrome) ScreenshotURL(targetURL *url.URL, destination string) error {
+	buf, err := chrome.Screenshot(context.Background(), targetURL.String())
+	if err != nil {
 		log.WithFields(log.Fields{"url": targetURL, "destination": destination, "err": err}).
 			Error("Screenshot failed")
+		return err
+	}
 
-		return
+	if err := ioutil.WriteFile(destination, buf, 0644); err != nil {
+		log.WithFields(log.Fields{"url": targetURL, "destination": destination, "err": err}).
+			Error("Failed to write screenshot to disk")
+		return err
 	}
 
-	log.WithFields(log.Fields{
-		"url": targetURL, "destination": destination, "duration": time.Since(startTime),
-	}).Info("Screenshot taken")
+	return nil
 }
@@ -0,0 +1,69 @@
+// Package downloader fetches the media a matched profile exposes
+// (pictures, videos) once a site has been confirmed to have the
+// username. Site-specific logic lives behind the Downloader interface so
+// main.go can dispatch by Match rather than a hardcoded site name.
+package downloader
+
+import (
+	"context"
+)
+
+// Asset is a single downloaded (or, in MetadataOnly mode, discovered)
+// piece of media.
+type Asset struct {
+	URL      string
+	Path     string
+	MimeType string
+}
+
+// Options controls how a Downloader fetches media.
+type Options struct {
+	// Concurrency is the number of assets downloaded in parallel.
+	// <= 0 means the Downloader picks its own default.
+	Concurrency int
+	// MaxItems caps how many assets are downloaded. <= 0 means no cap.
+	MaxItems int
+	// CookieFile, if set, is a Netscape-format cookie jar file used for
+	// requests that need authentication (Twitter/X media, private
+	// Instagram profiles, etc).
+	CookieFile string
+	// MetadataOnly skips downloading bytes and only returns Assets with
+	// URL/MimeType populated.
+	MetadataOnly bool
+}
+
+// Downloader fetches media for profile URLs it recognises.
+type Downloader interface {
+	// Name is the short, lowercase identifier used with --download and
+	// in the Impls map (e.g. "instagram").
+	Name() string
+	// Match reports whether url is a profile this Downloader can handle.
+	Match(url string) bool
+	// Download fetches url's media into outDir according to opts.
+	Download(ctx context.Context, url, outDir string, opts Options) ([]Asset, error)
+}
+
+// Impls holds every registered Downloader, keyed by Name().
+var Impls = map[string]Downloader{}
+
+func register(d Downloader) {
+	Impls[d.Name()] = d
+}
+
+func init() {
+	register(&instagramDownloader{})
+	register(&tiktokDownloader{})
+	register(&twitterDownloader{})
+	register(&youtubeDownloader{})
+}
+
+// Match returns the first registered Downloader willing to handle url,
+// or nil if none match.
+func Match(url string) Downloader {
+	for _, d := range Impls {
+		if d.Match(url) {
+			return d
+		}
+	}
+	return nil
+}
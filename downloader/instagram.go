@@ -1,11 +1,14 @@
 package downloader
 
 import (
+	"context"
+	"fmt"
 	"io"
-	"io/ioutil"
-	"log"
 	"net/http"
+	"net/http/cookiejar"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -13,70 +16,215 @@ import (
 	"github.com/tidwall/gjson"
 )
 
-func downloadInstagram(url string, logger *log.Logger) {
-	_splitURL := strings.Split(url, "/")
-	username := _splitURL[len(_splitURL)-1]
+// instagramWebProfileInfoURL is Instagram's current profile API.
+// The old `?__a=1` JSON endpoint this downloader used to rely on has
+// been dead for years.
+const instagramWebProfileInfoURL = "https://i.instagram.com/api/v1/users/web_profile_info/?username=%s"
 
-	OUT := "./downloads/" + username + "/instagram/"
-	os.MkdirAll(OUT, os.ModePerm)
+type instagramDownloader struct{}
 
-	var targetURIs []string
-	var wg sync.WaitGroup
+func (instagramDownloader) Name() string { return "instagram" }
 
-	r, err := http.Get(url + "?__a=1")
+func (instagramDownloader) Match(rawURL string) bool {
+	u, err := url.Parse(rawURL)
 	if err != nil {
-		log.Fatal(err)
+		return false
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	return host == "instagram.com"
+}
+
+func (d instagramDownloader) Download(ctx context.Context, rawURL, outDir string, opts Options) ([]Asset, error) {
+	username := strings.Trim(strings.TrimSuffix(rawURL, "/")[strings.LastIndex(strings.TrimSuffix(rawURL, "/"), "/")+1:], "/")
+
+	client, err := httpClientWithCookies(opts.CookieFile)
+	if err != nil {
+		return nil, fmt.Errorf("instagram: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(instagramWebProfileInfoURL, username), nil)
+	if err != nil {
+		return nil, fmt.Errorf("instagram: %w", err)
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Instagram-Downloader)")
+	req.Header.Set("X-IG-App-ID", "936619743392459")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("instagram: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("instagram: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("instagram: web_profile_info returned %s", resp.Status)
 	}
-	bdB, _ := ioutil.ReadAll(r.Body)
-	r.Body.Close()
 
-	targetURIs = append(targetURIs, gjson.GetBytes(bdB, "graphql.user.profile_pic_url_hd").String())
+	var assets []Asset
 
-	addURIFromNode := func(node gjson.Result) {
-		var targetURI string
+	addAsset := func(uri string) {
+		if uri != "" {
+			assets = append(assets, Asset{URL: uri})
+		}
+	}
+
+	addAsset(gjson.GetBytes(body, "data.user.profile_pic_url_hd").String())
+
+	addFromNode := func(node gjson.Result) {
 		if node.Get("is_video").Bool() {
-			targetURI = node.Get("video_url").String()
+			addAsset(node.Get("video_url").String())
 		} else {
-			targetURI = node.Get("display_url").String()
+			addAsset(node.Get("display_url").String())
 		}
-		targetURIs = append(targetURIs, targetURI)
 	}
 
-	for _, edge := range gjson.GetBytes(bdB, "graphql.user.edge_owner_to_timeline_media.edges").Array() {
+	for _, edge := range gjson.GetBytes(body, "data.user.edge_owner_to_timeline_media.edges").Array() {
 		node := edge.Get("node")
-		addURIFromNode(node)
-		for i, subEdge := range node.Get("edge_sidecar_to_children.edges").Array() {
-			if i != 0 {
-				subNode := subEdge.Get("node")
-				addURIFromNode(subNode)
-			}
+		addFromNode(node)
+		for _, subEdge := range node.Get("edge_sidecar_to_children.edges").Array() {
+			addFromNode(subEdge.Get("node"))
 		}
+
+		if opts.MaxItems > 0 && len(assets) >= opts.MaxItems {
+			break
+		}
+	}
+
+	if opts.MaxItems > 0 && len(assets) > opts.MaxItems {
+		assets = assets[:opts.MaxItems]
+	}
+
+	if opts.MetadataOnly {
+		return assets, nil
+	}
+
+	destDir := filepath.Join(outDir, username, "instagram")
+	if err := os.MkdirAll(destDir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("instagram: %w", err)
 	}
 
-	wg.Add(len(targetURIs))
-	for i, uri := range targetURIs {
-		go func(i int, uri string) {
+	return downloadAll(ctx, "instagram", client, assets, destDir, opts.Concurrency)
+}
+
+// httpClientWithCookies builds an *http.Client carrying the cookies in
+// cookieFile (Netscape format), or a plain client if cookieFile is empty.
+func httpClientWithCookies(cookieFile string) (*http.Client, error) {
+	if cookieFile == "" {
+		return http.DefaultClient, nil
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	cookies, host, err := parseNetscapeCookieFile(cookieFile)
+	if err != nil {
+		return nil, err
+	}
+
+	jar.SetCookies(&url.URL{Scheme: "https", Host: host}, cookies)
+
+	return &http.Client{Jar: jar}, nil
+}
+
+func parseNetscapeCookieFile(path string) ([]*http.Cookie, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var cookies []*http.Cookie
+	var host string
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 7 {
+			continue
+		}
+
+		host = strings.TrimPrefix(fields[0], ".")
+		cookies = append(cookies, &http.Cookie{Name: fields[5], Value: fields[6]})
+	}
+
+	return cookies, host, nil
+}
+
+// downloadAll fetches every asset's bytes into destDir using up to
+// concurrency workers, returning each Asset with its on-disk Path set.
+// name identifies the calling downloader (e.g. "tiktok") in error
+// messages, since this helper is shared across every back-end.
+func downloadAll(ctx context.Context, name string, client *http.Client, assets []Asset, destDir string, concurrency int) ([]Asset, error) {
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	var (
+		wg    sync.WaitGroup
+		guard = make(chan struct{}, concurrency)
+		mu    sync.Mutex
+		errs  []error
+	)
+
+	for i, asset := range assets {
+		wg.Add(1)
+		guard <- struct{}{}
+		go func(i int, asset Asset) {
 			defer wg.Done()
-			_splitURL := strings.Split(strings.Split(uri, "?")[0], ".")
+			defer func() { <-guard }()
 
-			file, err := os.Create(OUT + strconv.Itoa(i) + "." + _splitURL[len(_splitURL)-1])
+			path, err := downloadAsset(ctx, client, asset.URL, destDir, i)
+			mu.Lock()
+			defer mu.Unlock()
 			if err != nil {
-				log.Fatal(err)
+				errs = append(errs, err)
+				return
 			}
+			assets[i].Path = path
+		}(i, asset)
+	}
 
-			r, err := http.Get(uri)
-			if err != nil {
-				log.Fatal(err)
-			}
+	wg.Wait()
 
-			_, err = io.Copy(file, r.Body)
-			if err != nil {
-				log.Fatal(err)
-			}
+	if len(errs) > 0 {
+		return assets, fmt.Errorf("%s: %d of %d downloads failed: %w", name, len(errs), len(assets), errs[0])
+	}
 
-			r.Body.Close()
-			file.Close()
-		}(i, uri)
+	return assets, nil
+}
+
+func downloadAsset(ctx context.Context, client *http.Client, assetURL, destDir string, index int) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, assetURL, nil)
+	if err != nil {
+		return "", err
 	}
-	wg.Wait()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	ext := strings.Split(strings.Split(assetURL, "?")[0], ".")
+	path := filepath.Join(destDir, strconv.Itoa(index)+"."+ext[len(ext)-1])
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		return "", err
+	}
+
+	return path, nil
 }
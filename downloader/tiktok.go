@@ -0,0 +1,126 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// tiktokItemListURL lists a user's public videos by secUid, the opaque
+// identifier TikTok's web app uses internally instead of the username.
+const tiktokItemListURL = "https://www.tiktok.com/api/post/item_list/?aid=1988&count=%d&secUid=%s"
+
+var tiktokSecUIDPattern = regexp.MustCompile(`"secUid":"([^"]+)"`)
+
+type tiktokDownloader struct{}
+
+func (tiktokDownloader) Name() string { return "tiktok" }
+
+func (tiktokDownloader) Match(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	return host == "tiktok.com"
+}
+
+func (d tiktokDownloader) Download(ctx context.Context, rawURL, outDir string, opts Options) ([]Asset, error) {
+	username := strings.Trim(strings.TrimSuffix(rawURL, "/")[strings.LastIndex(strings.TrimSuffix(rawURL, "/"), "/")+1:], "@")
+
+	client, err := httpClientWithCookies(opts.CookieFile)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok: %w", err)
+	}
+
+	secUID, err := d.resolveSecUID(ctx, client, rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok: %w", err)
+	}
+
+	count := opts.MaxItems
+	if count <= 0 {
+		count = 30
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(tiktokItemListURL, count, secUID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok: %w", err)
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (TikTok-Downloader)")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tiktok: item_list returned %s", resp.Status)
+	}
+
+	var assets []Asset
+	for _, item := range gjson.GetBytes(body, "itemList").Array() {
+		playURL := item.Get("video.playAddr").String()
+		if playURL == "" {
+			continue
+		}
+		assets = append(assets, Asset{URL: playURL, MimeType: "video/mp4"})
+
+		if opts.MaxItems > 0 && len(assets) >= opts.MaxItems {
+			break
+		}
+	}
+
+	if opts.MetadataOnly {
+		return assets, nil
+	}
+
+	destDir := filepath.Join(outDir, username, "tiktok")
+	if err := os.MkdirAll(destDir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("tiktok: %w", err)
+	}
+
+	return downloadAll(ctx, "tiktok", client, assets, destDir, opts.Concurrency)
+}
+
+// resolveSecUID scrapes a TikTok profile page for the secUid embedded in
+// its hydration data, since the item_list API has no way to look a user
+// up by @handle directly.
+func (tiktokDownloader) resolveSecUID(ctx context.Context, client *http.Client, profileURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, profileURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (TikTok-Downloader)")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	match := tiktokSecUIDPattern.FindSubmatch(body)
+	if match == nil {
+		return "", fmt.Errorf("could not find secUid in profile page")
+	}
+
+	return string(match[1]), nil
+}
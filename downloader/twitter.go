@@ -0,0 +1,137 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// twitterGraphQLUserMediaURL is Twitter/X's internal GraphQL endpoint for
+// a profile's Media tab. The query ID is pinned to the version of the
+// operation this downloader was written against; Twitter rotates these
+// periodically.
+const twitterGraphQLUserMediaURL = "https://twitter.com/i/api/graphql/2L1teG0DF0DkvG3dnhzkQQ/UserMedia"
+
+type twitterDownloader struct {
+	// BearerToken is the public bearer token Twitter's web app ships,
+	// overridable for callers with their own app credentials.
+	BearerToken string
+}
+
+func (twitterDownloader) Name() string { return "twitter" }
+
+func (twitterDownloader) Match(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	return host == "twitter.com" || host == "x.com"
+}
+
+func (d twitterDownloader) Download(ctx context.Context, rawURL, outDir string, opts Options) ([]Asset, error) {
+	username := strings.Trim(strings.TrimSuffix(rawURL, "/")[strings.LastIndex(strings.TrimSuffix(rawURL, "/"), "/")+1:], "/")
+
+	if opts.CookieFile == "" {
+		return nil, fmt.Errorf("twitter: --download-cookies is required to query the Media tab")
+	}
+
+	client, err := httpClientWithCookies(opts.CookieFile)
+	if err != nil {
+		return nil, fmt.Errorf("twitter: %w", err)
+	}
+
+	variables := fmt.Sprintf(`{"screen_name":%q,"count":%d,"withSuperFollowsUserFields":true}`,
+		username, maxOrDefault(opts.MaxItems, 40))
+
+	reqURL := twitterGraphQLUserMediaURL + "?variables=" + url.QueryEscape(variables)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("twitter: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+d.bearerToken())
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Twitter-Downloader)")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("twitter: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("twitter: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("twitter: UserMedia returned %s", resp.Status)
+	}
+
+	var assets []Asset
+	for _, entry := range gjson.GetBytes(body, "data.user.result.timeline.timeline.instructions.#.entries|@flatten").Array() {
+		for _, media := range entry.Get("content.itemContent.tweet_results.result.legacy.extended_entities.media").Array() {
+			mediaURL := bestMediaURL(media)
+			if mediaURL == "" {
+				continue
+			}
+			assets = append(assets, Asset{URL: mediaURL})
+
+			if opts.MaxItems > 0 && len(assets) >= opts.MaxItems {
+				break
+			}
+		}
+	}
+
+	if opts.MetadataOnly {
+		return assets, nil
+	}
+
+	destDir := filepath.Join(outDir, username, "twitter")
+	if err := os.MkdirAll(destDir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("twitter: %w", err)
+	}
+
+	return downloadAll(ctx, "twitter", client, assets, destDir, opts.Concurrency)
+}
+
+func (d twitterDownloader) bearerToken() string {
+	if d.BearerToken != "" {
+		return d.BearerToken
+	}
+	// Twitter's web client ships this token publicly; it identifies the
+	// app, not a user, and carries no account privileges on its own.
+	return "AAAAAAAAAAAAAAAAAAAAANRILgAAAAAAnNwIzUejRCOuH5E6I8xnZz4puTs=1Zv7ttfk8LF81IUq16cHjhLTvJu4FA33AGWWjCpTnA"
+}
+
+func bestMediaURL(media gjson.Result) string {
+	if variants := media.Get("video_info.variants"); variants.Exists() {
+		var best gjson.Result
+		var bestBitrate int64
+		for _, v := range variants.Array() {
+			if v.Get("content_type").String() != "video/mp4" {
+				continue
+			}
+			if bitrate := v.Get("bitrate").Int(); bitrate >= bestBitrate {
+				bestBitrate = bitrate
+				best = v
+			}
+		}
+		return best.Get("url").String()
+	}
+
+	return media.Get("media_url_https").String()
+}
+
+func maxOrDefault(n, def int) int {
+	if n > 0 {
+		return n
+	}
+	return def
+}
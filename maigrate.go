@@ -1,22 +1,27 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"image/color"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 
 	color "github.com/fatih/color"
+	"github.com/krishpranav/maigrate/chrome"
 	"github.com/krishpranav/maigrate/downloader"
+	"github.com/krishpranav/maigrate/phash"
+	"github.com/krishpranav/maigrate/report"
+	"github.com/krishpranav/maigrate/useragent"
 )
 
 const (
-	userAgent       string = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/90.0.4430.93 Safari/537.36"
 	screenShotRes   string = "1024x768"
 	torProxyAddress string = "socks5://127.0.0.1:9050"
 )
@@ -24,18 +29,40 @@ const (
 var (
 	maxGoroutines int = 32
 	guard         chan int
+
+	// userAgent is the User-Agent used for initializeSiteData's one-off,
+	// single-threaded data.json download. Per-site scans get their UA
+	// straight from uaProvider at each call site below instead of through
+	// this var, since up to maxGoroutines scans run concurrently and a
+	// shared mutable global can't keep "same site, same UA" straight
+	// across them.
+	userAgent  = useragent.DefaultUserAgent
+	uaProvider *useragent.Provider
+
+	activeReporter Reporter
 )
 
+// chatty reports whether human-facing progress messages (the per-username
+// banner, the data.json download progress) should be printed to stdout.
+// They're suppressed outside --output text so piping into jq/a CSV parser
+// gets a clean machine-readable stream instead of text prepended to it.
+func chatty() bool {
+	return outputFormat == "text"
+}
+
 type Result struct {
-	Username string
-	Exist    bool
-	Proxied  bool
-	Site     string
-	URL      string
-	URLProbe string
-	Link     string
-	Err      bool
-	ErrMsg   string
+	Username       string
+	Exist          bool
+	Proxied        bool
+	Site           string
+	URL            string
+	URLProbe       string
+	Link           string
+	Err            bool
+	ErrMsg         string
+	ScreenshotPath string
+	PHash          phash.Hash
+	FalsePositive  bool
 }
 
 var (
@@ -54,7 +81,33 @@ var (
 		withScreenshot  bool
 		specifySite     bool
 		download        bool
+		useDB           bool
 	}
+	dbPath         string
+	serveAddr      string
+	phashThreshold int = 8
+
+	userAgentFixed string
+	userAgentMode  string = string(useragent.ModeRotate)
+	uaCachePath    string = "ua-cache.json"
+
+	downloadOpts        = downloader.Options{Concurrency: 4}
+	downloadOut  string = "./downloads"
+
+	outputFormat string = "text"
+	outputFile   string
+
+	// negativeBaselines caches the pHash of each site's
+	// username_unclaimed page, used to flag likely false positives.
+	negativeBaselines   = map[string]phash.Hash{}
+	negativeBaselinesMu sync.Mutex
+
+	// screenshotChrome is the single persistent browser used both to
+	// capture negative baselines and to screenshot real matches, shared
+	// across goroutines the same way Investigo shares the rest of the
+	// scan's resources.
+	screenshotChrome *chrome.Chrome
+	screenshotsDir   string = "./screenshots"
 )
 
 type SiteData struct {
@@ -89,6 +142,11 @@ func parseArguments() []string {
 	args := os.Args[1:]
 	var argIndex int
 
+	if len(args) > 0 && args[0] == "serve" {
+		runServe(args[1:])
+		os.Exit(0)
+	}
+
 	if help, _ := HasElement(args, "-h", "--help"); help && !options.runTest {
 		fmt.Print(
 			`maigrate - User Osint Across Social Networks.
@@ -107,6 +165,21 @@ flags:
 options:
         --database DATABASE   use custom database
         --site SITE           specific site to investigate
+        --db PATH             persist results to a SQLite database at PATH
+        --serve ADDRESS       serve an interactive report on ADDRESS (e.g. :8080)
+        --phash-threshold N   Hamming distance below which a screenshot is flagged a
+                              likely false positive against the site's negative baseline (default 8)
+        --user-agent UA       fixed User-Agent string to use (implies --user-agent-mode fixed)
+        --user-agent-mode M   fixed, rotate, or per-site (default rotate)
+        --ua-cache PATH       path to cache the weighted User-Agent pool (default ua-cache.json)
+        --download-out PATH           directory matched downloads are saved under (default ./downloads)
+        --download-cookies PATH       Netscape-format cookie jar for sites that require auth
+        --download-max-items N        cap how many assets are downloaded per site
+        --download-metadata-only      list matched asset URLs without downloading their bytes
+        --output FORMAT       text, json, ndjson, or csv (default text)
+        --output-file PATH    where to write --output results (default stdout)
+subcommand:
+        serve --db PATH --serve ADDRESS   launch the report server against an existing database
 `,
 		)
 		os.Exit(0)
@@ -168,6 +241,42 @@ options:
 		args = append(args[:argIndex], args[argIndex+2:]...)
 	}
 
+	options.useDB, argIndex = HasElement(args, "--db")
+	if options.useDB {
+		dbPath = args[argIndex+1]
+		args = append(args[:argIndex], args[argIndex+2:]...)
+	}
+
+	if hasServe, idx := HasElement(args, "--serve"); hasServe {
+		serveAddr = args[idx+1]
+		args = append(args[:idx], args[idx+2:]...)
+	}
+
+	if hasThreshold, idx := HasElement(args, "--phash-threshold"); hasThreshold {
+		n, err := strconv.Atoi(args[idx+1])
+		if err != nil {
+			log.Fatalf("[!] --phash-threshold expects an integer, got %q", args[idx+1])
+		}
+		phashThreshold = n
+		args = append(args[:idx], args[idx+2:]...)
+	}
+
+	if hasUA, idx := HasElement(args, "--user-agent"); hasUA {
+		userAgentFixed = args[idx+1]
+		userAgentMode = string(useragent.ModeFixed)
+		args = append(args[:idx], args[idx+2:]...)
+	}
+
+	if hasMode, idx := HasElement(args, "--user-agent-mode"); hasMode {
+		userAgentMode = args[idx+1]
+		args = append(args[:idx], args[idx+2:]...)
+	}
+
+	if hasCache, idx := HasElement(args, "--ua-cache"); hasCache {
+		uaCachePath = args[idx+1]
+		args = append(args[:idx], args[idx+2:]...)
+	}
+
 	options.download, argIndex = HasElement(args, "-d", "--download")
 	if options.download {
 		if len(args) <= 1 {
@@ -180,12 +289,76 @@ options:
 		args = append(args[:argIndex], args[argIndex+1:]...)
 	}
 
+	if hasOut, idx := HasElement(args, "--download-out"); hasOut {
+		downloadOut = args[idx+1]
+		args = append(args[:idx], args[idx+2:]...)
+	}
+
+	if hasCookies, idx := HasElement(args, "--download-cookies"); hasCookies {
+		downloadOpts.CookieFile = args[idx+1]
+		args = append(args[:idx], args[idx+2:]...)
+	}
+
+	if hasMaxItems, idx := HasElement(args, "--download-max-items"); hasMaxItems {
+		n, err := strconv.Atoi(args[idx+1])
+		if err != nil {
+			log.Fatalf("[!] --download-max-items expects an integer, got %q", args[idx+1])
+		}
+		downloadOpts.MaxItems = n
+		args = append(args[:idx], args[idx+2:]...)
+	}
+
+	if hasMetadataOnly, idx := HasElement(args, "--download-metadata-only"); hasMetadataOnly {
+		downloadOpts.MetadataOnly = true
+		args = append(args[:idx], args[idx+1:]...)
+	}
+
+	if hasOutput, idx := HasElement(args, "--output"); hasOutput {
+		outputFormat = args[idx+1]
+		args = append(args[:idx], args[idx+2:]...)
+	}
+
+	if hasOutputFile, idx := HasElement(args, "--output-file"); hasOutputFile {
+		outputFile = args[idx+1]
+		args = append(args[:idx], args[idx+2:]...)
+	}
+
 	return args
 }
 
 func main() {
 	usernames := parseArguments()
 
+	var err error
+	uaProvider, err = useragent.NewProvider(useragent.Mode(userAgentMode), userAgentFixed, uaCachePath)
+	if err != nil {
+		log.Fatalf("[!] Failed to initialize User-Agent pool: %v", err)
+	}
+	userAgent = uaProvider.UserAgent("")
+
+	activeReporter, err = newReporter(outputFormat, outputFile)
+	if err != nil {
+		log.Fatalf("[!] %v", err)
+	}
+	defer activeReporter.Close()
+
+	if options.withScreenshot {
+		if err := os.MkdirAll(screenshotsDir, os.ModePerm); err != nil {
+			log.Fatalf("[!] Could not create screenshot directory %q: %v", screenshotsDir, err)
+		}
+
+		screenshotChrome = &chrome.Chrome{
+			Resolution:       screenShotRes,
+			ChromeTimeout:    15,
+			ChromeTimeBudget: 2,
+			UserAgent:        uaProvider.UserAgent(""),
+		}
+		if err := screenshotChrome.Setup(); err != nil {
+			log.Fatalf("[!] Could not start Chrome for screenshots: %v", err)
+		}
+		defer screenshotChrome.Close()
+	}
+
 	initializeSiteData(options.updateBeforeRun)
 
 	guard = make(chan int, maxGoroutines)
@@ -195,6 +368,24 @@ func main() {
 		os.Exit(0)
 	}
 
+	var store *report.Store
+	if options.useDB {
+		var err error
+		store, err = report.Open(dbPath)
+		if err != nil {
+			log.Fatalf("[!] Failed to open results database %q: %v", dbPath, err)
+		}
+		defer store.Close()
+
+		if serveAddr != "" {
+			go func() {
+				if err := report.Serve(serveAddr, store, "."); err != nil {
+					log.Printf("[!] Report server stopped: %v", err)
+				}
+			}()
+		}
+	}
+
 	if options.specifySite {
 		for _, username := range usernames {
 			_siteData := map[string]SiteData{}
@@ -203,34 +394,56 @@ func main() {
 				_siteData[strings.ToLower(siteName)] = v
 			}
 
-			if options.noColor {
-				fmt.Printf("\nInvestigating %s on:\n", username)
-			} else {
-				fmt.Fprintf(color.Output, "Investigating %s on:\n", color.HiGreenString(username))
+			if chatty() {
+				if options.noColor {
+					fmt.Printf("\nInvestigating %s on:\n", username)
+				} else {
+					fmt.Fprintf(color.Output, "Investigating %s on:\n", color.HiGreenString(username))
+				}
 			}
 			site := specifiedSites
 
 			if val, ok := _siteData[site]; ok {
-				res := Investigo(username, site, val)
-				WriteResult(res)
+				res := Investigo(username, site, val, uaProvider.UserAgent(site))
+				if options.withScreenshot {
+					investigateFalsePositive(&res)
+				}
+				activeReporter.Write(res)
+				if store != nil {
+					persistResult(store, res)
+				}
+				if options.download {
+					downloadMatched(res)
+				}
 			} else {
 				log.Printf("[!] %s is not a valid site.", site)
 			}
 		}
 	} else {
 		for _, username := range usernames {
-			if options.noColor {
-				fmt.Printf("\nInvestigating %s on:\n", username)
-			} else {
-				fmt.Fprintf(color.Output, "Investigating %s on:\n", color.HiGreenString(username))
+			if chatty() {
+				if options.noColor {
+					fmt.Printf("\nInvestigating %s on:\n", username)
+				} else {
+					fmt.Fprintf(color.Output, "Investigating %s on:\n", color.HiGreenString(username))
+				}
 			}
 			waitGroup.Add(len(siteData))
 			for site := range siteData {
 				guard <- 1
 				go func(site string) {
 					defer waitGroup.Done()
-					res := Investigo(username, site, siteData[site])
-					WriteResult(res)
+					res := Investigo(username, site, siteData[site], uaProvider.UserAgent(site))
+					if options.withScreenshot {
+						investigateFalsePositive(&res)
+					}
+					activeReporter.Write(res)
+					if store != nil {
+						persistResult(store, res)
+					}
+					if options.download {
+						downloadMatched(res)
+					}
 					<-guard
 				}(site)
 			}
@@ -242,7 +455,7 @@ func main() {
 func initializeSiteData(forceUpdate bool) {
 	jsonFile, err := os.Open(dataFileName)
 	if err != nil || forceUpdate {
-		if err != nil {
+		if err != nil && chatty() {
 			if options.noColor {
 				fmt.Printf(
 					"[!] Cannot open database \"%s\"\n",
@@ -256,19 +469,21 @@ func initializeSiteData(forceUpdate bool) {
 				)
 			}
 		}
-		if options.noColor {
-			fmt.Printf(
-				"%s Update database: %s",
-				("[!]"),
-				("Downloading..."),
-			)
-		} else {
-			fmt.Fprintf(
-				color.Output,
-				"[%s] Update database: %s",
-				color.HiBlueString("!"),
-				color.HiYellowString("Downloading..."),
-			)
+		if chatty() {
+			if options.noColor {
+				fmt.Printf(
+					"%s Update database: %s",
+					("[!]"),
+					("Downloading..."),
+				)
+			} else {
+				fmt.Fprintf(
+					color.Output,
+					"[%s] Update database: %s",
+					color.HiBlueString("!"),
+					color.HiYellowString("Downloading..."),
+				)
+			}
 		}
 
 		if forceUpdate {
@@ -278,10 +493,12 @@ func initializeSiteData(forceUpdate bool) {
 		r, err := Request("https://raw.githubusercontent.com/sherlock-project/sherlock/master/sherlock/resources/data.json")
 
 		if err != nil || r.StatusCode != 200 {
-			if options.noColor {
-				fmt.Printf(" [%s]\n", ("Failed"))
-			} else {
-				fmt.Fprintf(color.Output, " [%s]\n", color.HiRedString("Failed"))
+			if chatty() {
+				if options.noColor {
+					fmt.Printf(" [%s]\n", ("Failed"))
+				} else {
+					fmt.Fprintf(color.Output, " [%s]\n", color.HiRedString("Failed"))
+				}
 			}
 			if err != nil {
 				panic("Failed to update database.\n" + err.Error())
@@ -298,10 +515,12 @@ func initializeSiteData(forceUpdate bool) {
 		}
 		_updateFile, _ := os.OpenFile(dataFileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 		if _, err := _updateFile.WriteString(ReadResponseBody(r)); err != nil {
-			if options.noColor {
-				fmt.Printf("Failed to update data.\n")
-			} else {
-				fmt.Fprint(color.Output, color.RedString("Failed to update data.\n"))
+			if chatty() {
+				if options.noColor {
+					fmt.Printf("Failed to update data.\n")
+				} else {
+					fmt.Fprint(color.Output, color.RedString("Failed to update data.\n"))
+				}
 			}
 			panic(err)
 		}
@@ -309,10 +528,12 @@ func initializeSiteData(forceUpdate bool) {
 		_updateFile.Close()
 		jsonFile, _ = os.Open(dataFileName)
 
-		if options.noColor {
-			fmt.Println(" [Done]")
-		} else {
-			fmt.Fprintf(color.Output, " [%s]\n", color.GreenString("Done"))
+		if chatty() {
+			if options.noColor {
+				fmt.Println(" [Done]")
+			} else {
+				fmt.Fprintf(color.Output, " [%s]\n", color.GreenString("Done"))
+			}
 		}
 	}
 
@@ -324,4 +545,163 @@ func initializeSiteData(forceUpdate bool) {
 	} else {
 		json.Unmarshal([]byte(byteValue), &siteData)
 	}
+
+	if options.withScreenshot {
+		captureNegativeBaselines()
+	}
+}
+
+// captureNegativeBaselines screenshots each site's username_unclaimed
+// page and caches its pHash, giving investigateFalsePositive something
+// to compare real results against. UnusedUsername is a bare username
+// (Sherlock's "username_unclaimed"), so it has to be substituted into
+// URL's "{}" placeholder the same way a real probe URL is built.
+func captureNegativeBaselines() {
+	if screenshotChrome == nil {
+		return
+	}
+
+	for site, data := range siteData {
+		if data.UnusedUsername == "" || data.URL == "" {
+			continue
+		}
+
+		probeURL := strings.Replace(data.URL, "{}", data.UnusedUsername, -1)
+
+		buf, err := screenshotChrome.Screenshot(context.Background(), probeURL)
+		if err != nil {
+			log.Printf("[!] Failed to capture negative baseline for %s: %v", site, err)
+			continue
+		}
+
+		hash, err := phash.Compute(buf)
+		if err != nil {
+			log.Printf("[!] Failed to hash negative baseline for %s: %v", site, err)
+			continue
+		}
+
+		negativeBaselinesMu.Lock()
+		negativeBaselines[site] = hash
+		negativeBaselinesMu.Unlock()
+	}
+}
+
+// investigateFalsePositive screenshots res's matched URL, saves it under
+// screenshotsDir, and flags res as a likely false positive when its
+// pHash is within phashThreshold bits of the site's negative baseline.
+// Only meaningful for actual matches, so it's a no-op for res.Exist ==
+// false (the common case across a full scan).
+func investigateFalsePositive(res *Result) {
+	if !res.Exist || screenshotChrome == nil || res.URL == "" {
+		return
+	}
+
+	buf, err := screenshotChrome.Screenshot(context.Background(), res.URL)
+	if err != nil {
+		log.Printf("[!] Failed to capture screenshot for %s/%s: %v", res.Username, res.Site, err)
+		return
+	}
+
+	path := filepath.Join(screenshotsDir, res.Username+"_"+res.Site+".png")
+	if err := ioutil.WriteFile(path, buf, 0644); err != nil {
+		log.Printf("[!] Failed to write screenshot %s: %v", path, err)
+		return
+	}
+	res.ScreenshotPath = path
+
+	hash, err := phash.Compute(buf)
+	if err != nil {
+		log.Printf("[!] Failed to hash screenshot %s: %v", path, err)
+		return
+	}
+	res.PHash = hash
+
+	negativeBaselinesMu.Lock()
+	baseline, ok := negativeBaselines[res.Site]
+	negativeBaselinesMu.Unlock()
+	if !ok {
+		return
+	}
+
+	res.FalsePositive = phash.Distance(hash, baseline) <= phashThreshold
+}
+
+// persistResult mirrors a scan Result into the results database so that
+// history survives past this run and the report server has something to
+// show.
+func persistResult(store *report.Store, res Result) {
+	if err := store.Save(report.Result{
+		Username:       res.Username,
+		Site:           res.Site,
+		URL:            res.URL,
+		URLProbe:       res.URLProbe,
+		Exists:         res.Exist,
+		Proxied:        res.Proxied,
+		Err:            res.Err,
+		ErrMsg:         res.ErrMsg,
+		ScreenshotPath: res.ScreenshotPath,
+		PHash:          uint64(res.PHash),
+		FalsePositive:  res.FalsePositive,
+	}); err != nil {
+		log.Printf("[!] Failed to persist result for %s/%s: %v", res.Username, res.Site, err)
+	}
+}
+
+// downloadMatched dispatches res.URL to whichever registered Downloader
+// claims it, by Match rather than a hardcoded site name, and logs
+// failures instead of aborting the whole run.
+func downloadMatched(res Result) {
+	if !res.Exist {
+		return
+	}
+
+	dl := downloader.Match(res.URL)
+	if dl == nil {
+		return
+	}
+
+	assets, err := dl.Download(context.Background(), res.URL, downloadOut, downloadOpts)
+	if err != nil {
+		log.Printf("[!] %s: download failed for %s: %v", dl.Name(), res.URL, err)
+		return
+	}
+
+	log.Printf("[+] %s: downloaded %d asset(s) for %s", dl.Name(), len(assets), res.URL)
+}
+
+// runServe implements the `maigrate serve` subcommand: it opens an
+// existing results database and blocks serving the interactive report
+// until the process is killed.
+func runServe(args []string) {
+	var db, addr string
+
+	if hasDB, idx := HasElement(args, "--db"); hasDB {
+		db = args[idx+1]
+		args = append(args[:idx], args[idx+2:]...)
+	}
+
+	if hasAddr, idx := HasElement(args, "--serve"); hasAddr {
+		addr = args[idx+1]
+		args = append(args[:idx], args[idx+2:]...)
+	}
+
+	if db == "" {
+		log.Fatal("[!] `maigrate serve` requires --db path.sqlite3")
+	}
+
+	if addr == "" {
+		addr = ":8080"
+	}
+
+	store, err := report.Open(db)
+	if err != nil {
+		log.Fatalf("[!] Failed to open results database %q: %v", db, err)
+	}
+	defer store.Close()
+
+	fmt.Fprintf(color.Output, "[%s] Serving report on %s\n", color.HiGreenString("+"), addr)
+
+	if err := report.Serve(addr, store, "."); err != nil {
+		log.Fatalf("[!] Report server failed: %v", err)
+	}
 }
@@ -0,0 +1,155 @@
+// Package phash computes perceptual image hashes so near-identical
+// screenshots (e.g. the soft-404 "user not found" page a site serves for
+// every nonexistent username) can be recognised without a full image
+// comparison. It has no dependency beyond the standard image decoders.
+package phash
+
+import (
+	"bytes"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+const (
+	// sampleSize is the side length screenshots are downsampled to
+	// before the DCT is taken.
+	sampleSize = 32
+	// lowFreq is the side length of the top-left, low-frequency
+	// coefficient block kept from the DCT output.
+	lowFreq = 8
+)
+
+// Hash is a 64-bit perceptual fingerprint. Two hashes with a small
+// Hamming distance (see Distance) correspond to visually similar images.
+type Hash uint64
+
+// Compute decodes a PNG or JPEG screenshot and returns its pHash: the
+// image is reduced to a 32x32 grayscale sample, a 2D DCT is taken, and
+// the 8x8 low-frequency coefficients (excluding the DC term) are
+// thresholded against their median to produce a 64-bit fingerprint.
+func Compute(imageBytes []byte) (Hash, error) {
+	img, _, err := image.Decode(bytes.NewReader(imageBytes))
+	if err != nil {
+		return 0, err
+	}
+
+	samples := grayscaleSamples(img, sampleSize, sampleSize)
+	coefficients := dct2D(samples)
+
+	values := make([]float64, 0, lowFreq*lowFreq-1)
+	for y := 0; y < lowFreq; y++ {
+		for x := 0; x < lowFreq; x++ {
+			if x == 0 && y == 0 {
+				// Skip the DC term: it reflects overall brightness,
+				// not structure, and would bias the threshold.
+				continue
+			}
+			values = append(values, coefficients[y][x])
+		}
+	}
+
+	threshold := median(values)
+
+	var hash Hash
+	for i, v := range values {
+		if v > threshold {
+			hash |= 1 << uint(i)
+		}
+	}
+
+	return hash, nil
+}
+
+// Distance returns the Hamming distance between two hashes: the number
+// of differing bits. 0 means identical fingerprints; larger values mean
+// less visual similarity.
+func Distance(a, b Hash) int {
+	return bits.OnesCount64(uint64(a ^ b))
+}
+
+// grayscaleSamples downsamples img to a w x h grid of luma values using
+// nearest-neighbour sampling, which is sufficient for a perceptual hash.
+func grayscaleSamples(img image.Image, w, h int) [][]float64 {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+
+	samples := make([][]float64, h)
+	for y := 0; y < h; y++ {
+		samples[y] = make([]float64, w)
+		sy := bounds.Min.Y + y*srcH/h
+		for x := 0; x < w; x++ {
+			sx := bounds.Min.X + x*srcW/w
+			r, g, b, _ := img.At(sx, sy).RGBA()
+			samples[y][x] = 0.299*float64(r>>8) + 0.587*float64(g>>8) + 0.114*float64(b>>8)
+		}
+	}
+
+	return samples
+}
+
+// dct2D applies a 2D discrete cosine transform by running a 1D DCT over
+// rows, then over the resulting columns.
+func dct2D(matrix [][]float64) [][]float64 {
+	rows := len(matrix)
+	cols := len(matrix[0])
+
+	rowTransformed := make([][]float64, rows)
+	for y, row := range matrix {
+		rowTransformed[y] = dct1D(row)
+	}
+
+	out := make([][]float64, rows)
+	for y := range out {
+		out[y] = make([]float64, cols)
+	}
+
+	column := make([]float64, rows)
+	for x := 0; x < cols; x++ {
+		for y := 0; y < rows; y++ {
+			column[y] = rowTransformed[y][x]
+		}
+		column = dct1D(column)
+		for y := 0; y < rows; y++ {
+			out[y][x] = column[y]
+		}
+	}
+
+	return out
+}
+
+func dct1D(in []float64) []float64 {
+	n := len(in)
+	out := make([]float64, n)
+
+	for k := 0; k < n; k++ {
+		var sum float64
+		for i, v := range in {
+			sum += v * math.Cos(math.Pi/float64(n)*(float64(i)+0.5)*float64(k))
+		}
+
+		alpha := math.Sqrt(2.0 / float64(n))
+		if k == 0 {
+			alpha = math.Sqrt(1.0 / float64(n))
+		}
+
+		out[k] = alpha * sum
+	}
+
+	return out
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+
+	return sorted[mid]
+}
@@ -0,0 +1,247 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strconv"
+)
+
+// Server renders an interactive, searchable view of a Store's results:
+// a table of findings per username, a screenshot thumbnail gallery, and
+// JSON/CSV export endpoints.
+type Server struct {
+	store          *Store
+	screenshotsDir string
+	mux            *http.ServeMux
+}
+
+// NewServer wires up a Server backed by store. screenshotsDir is served
+// under /screenshots/ so the report can thumbnail images saved alongside
+// the database.
+func NewServer(store *Store, screenshotsDir string) *Server {
+	s := &Server{store: store, screenshotsDir: screenshotsDir, mux: http.NewServeMux()}
+
+	s.mux.HandleFunc("/", s.handleIndex)
+	s.mux.HandleFunc("/site/", s.handleSite)
+	s.mux.HandleFunc("/clusters", s.handleClusters)
+	s.mux.HandleFunc("/export.json", s.handleExportJSON)
+	s.mux.HandleFunc("/export.csv", s.handleExportCSV)
+	s.mux.Handle("/screenshots/", http.StripPrefix("/screenshots/", http.FileServer(http.Dir(screenshotsDir))))
+
+	return s
+}
+
+// Serve starts an HTTP server rendering the report on addr (e.g. ":8080").
+func Serve(addr string, store *Store, screenshotsDir string) error {
+	return http.ListenAndServe(addr, NewServer(store, screenshotsDir))
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`
+<!DOCTYPE html>
+<html>
+<head>
+	<title>maigrate report</title>
+	<style>
+		body { font-family: sans-serif; margin: 2em; }
+		table { border-collapse: collapse; width: 100%; }
+		th, td { border: 1px solid #ccc; padding: 0.4em 0.6em; text-align: left; }
+		th { cursor: pointer; background: #f5f5f5; }
+		tr.not-found { color: #999; }
+		img.thumb { max-width: 120px; max-height: 90px; }
+	</style>
+</head>
+<body>
+	<h1>maigrate report</h1>
+	<p><a href="/export.json">JSON</a> | <a href="/export.csv">CSV</a></p>
+	<p><input type="search" id="search" placeholder="Filter results..." size="40"></p>
+	<table id="results">
+		<thead>
+			<tr><th>Username</th><th>Site</th><th>Exists</th><th>URL</th><th>Screenshot</th><th>Likely false positive</th><th>Timestamp</th></tr>
+		</thead>
+		<tbody>
+			{{range .}}
+			<tr class="{{if not .Exists}}not-found{{end}}">
+				<td>{{.Username}}</td>
+				<td><a href="/site/{{.Site}}">{{.Site}}</a></td>
+				<td>{{.Exists}}</td>
+				<td><a href="{{.URL}}">{{.URL}}</a></td>
+				<td>{{if .ScreenshotPath}}<img class="thumb" src="/screenshots/{{.ScreenshotPath}}">{{end}}</td>
+				<td>{{if .FalsePositive}}likely{{end}}</td>
+				<td>{{.Timestamp}}</td>
+			</tr>
+			{{end}}
+		</tbody>
+	</table>
+	<script>
+		(function() {
+			var table = document.getElementById("results");
+			var tbody = table.tBodies[0];
+			var headers = table.tHead.rows[0].cells;
+
+			for (var i = 0; i < headers.length; i++) {
+				headers[i].addEventListener("click", function(col) {
+					return function() { sortByColumn(col); };
+				}(i));
+			}
+
+			var sortCol = -1, sortAsc = true;
+			function sortByColumn(col) {
+				sortAsc = (sortCol === col) ? !sortAsc : true;
+				sortCol = col;
+
+				var rows = Array.prototype.slice.call(tbody.rows);
+				rows.sort(function(a, b) {
+					var av = a.cells[col].textContent.trim();
+					var bv = b.cells[col].textContent.trim();
+					var an = parseFloat(av), bn = parseFloat(bv);
+					var cmp;
+					if (!isNaN(an) && !isNaN(bn) && String(an) === av && String(bn) === bv) {
+						cmp = an - bn;
+					} else {
+						cmp = av.localeCompare(bv);
+					}
+					return sortAsc ? cmp : -cmp;
+				});
+
+				rows.forEach(function(row) { tbody.appendChild(row); });
+			}
+
+			document.getElementById("search").addEventListener("input", function(e) {
+				var query = e.target.value.toLowerCase();
+				Array.prototype.forEach.call(tbody.rows, function(row) {
+					row.style.display = row.textContent.toLowerCase().indexOf(query) === -1 ? "none" : "";
+				});
+			});
+		})();
+	</script>
+</body>
+</html>
+`))
+
+var clustersTemplate = template.Must(template.New("clusters").Parse(`
+<!DOCTYPE html>
+<html>
+<head><title>maigrate report - likely false positives</title></head>
+<body>
+	<h1>Likely false positives, grouped by screenshot</h1>
+	{{range .}}
+	<h3>pHash {{printf "%x" .PHash}} &mdash; {{len .Results}} sites</h3>
+	<ul>
+		{{range .Results}}<li>{{.Site}} &mdash; {{.Username}} (<a href="{{.URL}}">{{.URL}}</a>)</li>{{end}}
+	</ul>
+	{{end}}
+</body>
+</html>
+`))
+
+// cluster groups Results that share the exact same pHash, surfacing the
+// "these N sites all returned the same generic page" view.
+type cluster struct {
+	PHash   uint64
+	Results []Result
+}
+
+func (s *Server) handleClusters(w http.ResponseWriter, r *http.Request) {
+	results, err := s.store.All()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	byHash := map[uint64][]Result{}
+	for _, res := range results {
+		if !res.FalsePositive {
+			continue
+		}
+		byHash[res.PHash] = append(byHash[res.PHash], res)
+	}
+
+	clusters := make([]cluster, 0, len(byHash))
+	for hash, group := range byHash {
+		clusters = append(clusters, cluster{PHash: hash, Results: group})
+	}
+
+	if err := clustersTemplate.Execute(w, clusters); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	results, err := s.store.All()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := indexTemplate.Execute(w, results); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleSite(w http.ResponseWriter, r *http.Request) {
+	site := filepath.Base(r.URL.Path)
+
+	results, err := s.store.BySite(site)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := indexTemplate.Execute(w, results); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleExportJSON(w http.ResponseWriter, r *http.Request) {
+	results, err := s.store.All()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (s *Server) handleExportCSV(w http.ResponseWriter, r *http.Request) {
+	results, err := s.store.All()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+
+	cw.Write([]string{"username", "site", "exists", "url", "url_probe", "proxied", "err", "err_msg", "screenshot_path", "phash", "false_positive", "timestamp"})
+	for _, res := range results {
+		cw.Write([]string{
+			res.Username,
+			res.Site,
+			strconv.FormatBool(res.Exists),
+			res.URL,
+			res.URLProbe,
+			strconv.FormatBool(res.Proxied),
+			strconv.FormatBool(res.Err),
+			res.ErrMsg,
+			res.ScreenshotPath,
+			fmt.Sprintf("%x", res.PHash),
+			strconv.FormatBool(res.FalsePositive),
+			res.Timestamp.Format("2006-01-02T15:04:05Z07:00"),
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package report
+
+import (
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Result is a single investigated (username, site) outcome persisted by a
+// Store. It carries everything WriteResult would otherwise print to
+// stdout, plus the screenshot/pHash metadata the scanner attaches when
+// --screenshot is enabled.
+type Result struct {
+	ID             int64
+	Username       string
+	Site           string
+	URL            string
+	URLProbe       string
+	Exists         bool
+	Proxied        bool
+	Err            bool
+	ErrMsg         string
+	ScreenshotPath string
+	PHash          uint64
+	FalsePositive  bool
+	Timestamp      time.Time
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS results (
+	id              INTEGER PRIMARY KEY AUTOINCREMENT,
+	username        TEXT NOT NULL,
+	site            TEXT NOT NULL,
+	url             TEXT NOT NULL,
+	url_probe       TEXT NOT NULL,
+	exists_         INTEGER NOT NULL,
+	proxied         INTEGER NOT NULL,
+	err             INTEGER NOT NULL,
+	err_msg         TEXT NOT NULL,
+	screenshot_path TEXT NOT NULL,
+	phash           INTEGER NOT NULL,
+	false_positive  INTEGER NOT NULL,
+	timestamp       DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_results_username ON results(username);
+CREATE INDEX IF NOT EXISTS idx_results_site ON results(site);
+`
+
+// Store persists Results to a SQLite database, giving scans a history
+// that survives past a single run of maigrate.
+type Store struct {
+	db *sql.DB
+}
+
+// Open creates (if necessary) and opens the SQLite database at path,
+// applying the results schema.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close releases the underlying database handle.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Save inserts a Result, stamping Timestamp if it is unset.
+func (s *Store) Save(r Result) error {
+	if r.Timestamp.IsZero() {
+		r.Timestamp = time.Now()
+	}
+
+	_, err := s.db.Exec(
+		`INSERT INTO results
+			(username, site, url, url_probe, exists_, proxied, err, err_msg, screenshot_path, phash, false_positive, timestamp)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Username, r.Site, r.URL, r.URLProbe, r.Exists, r.Proxied, r.Err, r.ErrMsg, r.ScreenshotPath, r.PHash, r.FalsePositive, r.Timestamp,
+	)
+	return err
+}
+
+// All returns every persisted Result, most recent first.
+func (s *Store) All() ([]Result, error) {
+	return s.query(`SELECT id, username, site, url, url_probe, exists_, proxied, err, err_msg, screenshot_path, phash, false_positive, timestamp
+		FROM results ORDER BY timestamp DESC`)
+}
+
+// ByUsername returns every persisted Result for a given username, most
+// recent first.
+func (s *Store) ByUsername(username string) ([]Result, error) {
+	return s.query(`SELECT id, username, site, url, url_probe, exists_, proxied, err, err_msg, screenshot_path, phash, false_positive, timestamp
+		FROM results WHERE username = ? ORDER BY timestamp DESC`, username)
+}
+
+// BySite returns every persisted Result for a given site, most recent
+// first. Used by the report server's per-site drill-down page.
+func (s *Store) BySite(site string) ([]Result, error) {
+	return s.query(`SELECT id, username, site, url, url_probe, exists_, proxied, err, err_msg, screenshot_path, phash, false_positive, timestamp
+		FROM results WHERE site = ? ORDER BY timestamp DESC`, site)
+}
+
+func (s *Store) query(q string, args ...interface{}) ([]Result, error) {
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []Result
+	for rows.Next() {
+		var r Result
+		if err := rows.Scan(&r.ID, &r.Username, &r.Site, &r.URL, &r.URLProbe, &r.Exists, &r.Proxied, &r.Err, &r.ErrMsg, &r.ScreenshotPath, &r.PHash, &r.FalsePositive, &r.Timestamp); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Reporter receives each Result as sites finish investigating and is
+// responsible for surfacing it to the user or downstream tooling. It
+// replaces calling WriteResult directly so --output can pick a machine
+// readable format instead of colored text.
+type Reporter interface {
+	// Write is called once per completed Result.
+	Write(res Result)
+	// Close flushes any buffered output (e.g. the JSON array) and
+	// releases the underlying writer.
+	Close() error
+}
+
+// newReporter builds the Reporter selected by format (text, json,
+// ndjson, or csv), writing to outputFile, or stdout if outputFile is
+// empty.
+func newReporter(format, outputFile string) (Reporter, error) {
+	if format == "" {
+		format = "text"
+	}
+
+	if format == "text" {
+		return textReporter{}, nil
+	}
+
+	w, closeFn, err := openOutput(outputFile)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case "json":
+		return &jsonReporter{w: w, closeFn: closeFn}, nil
+	case "ndjson":
+		return &ndjsonReporter{enc: json.NewEncoder(w), closeFn: closeFn}, nil
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{
+			"username", "site", "exists", "url", "url_probe", "proxied",
+			"error", "error_msg", "screenshot_path", "phash",
+		})
+		return &csvReporter{w: cw, closeFn: closeFn}, nil
+	default:
+		closeFn()
+		return nil, fmt.Errorf("unknown --output format %q (want text, json, ndjson, or csv)", format)
+	}
+}
+
+func openOutput(path string) (io.Writer, func() error, error) {
+	if path == "" {
+		return os.Stdout, func() error { return nil }, nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return f, f.Close, nil
+}
+
+// textReporter preserves the original human-readable, colored output.
+type textReporter struct{}
+
+func (textReporter) Write(res Result) { WriteResult(res) }
+func (textReporter) Close() error     { return nil }
+
+// jsonReporter buffers every Result and emits a single well-formed JSON
+// array once the scan finishes.
+type jsonReporter struct {
+	mu      sync.Mutex
+	results []Result
+	w       io.Writer
+	closeFn func() error
+}
+
+func (r *jsonReporter) Write(res Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, res)
+}
+
+func (r *jsonReporter) Close() error {
+	enc := json.NewEncoder(r.w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r.results); err != nil {
+		r.closeFn()
+		return err
+	}
+	return r.closeFn()
+}
+
+// ndjsonReporter streams one Result per line as sites complete, for
+// piping a long scan straight into jq/grep.
+type ndjsonReporter struct {
+	mu      sync.Mutex
+	enc     *json.Encoder
+	closeFn func() error
+}
+
+func (r *ndjsonReporter) Write(res Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.enc.Encode(res)
+}
+
+func (r *ndjsonReporter) Close() error { return r.closeFn() }
+
+// csvReporter streams rows with a stable column set as sites complete.
+type csvReporter struct {
+	mu      sync.Mutex
+	w       *csv.Writer
+	closeFn func() error
+}
+
+func (r *csvReporter) Write(res Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.w.Write([]string{
+		res.Username,
+		res.Site,
+		strconv.FormatBool(res.Exist),
+		res.URL,
+		res.URLProbe,
+		strconv.FormatBool(res.Proxied),
+		strconv.FormatBool(res.Err),
+		res.ErrMsg,
+		res.ScreenshotPath,
+		fmt.Sprintf("%x", res.PHash),
+	})
+	r.w.Flush()
+}
+
+func (r *csvReporter) Close() error {
+	return r.closeFn()
+}
@@ -0,0 +1,289 @@
+// Package useragent hands out realistic, weighted User-Agent strings so
+// requests don't all carry the same stale, easily-fingerprinted client
+// identifier. The weighting comes from caniuse's browser-share data,
+// cached to disk for 24h so a run doesn't refetch it every time.
+package useragent
+
+import (
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Mode selects how Provider.UserAgent picks a string on each call.
+type Mode string
+
+const (
+	// ModeFixed always returns the same, caller-supplied string.
+	ModeFixed Mode = "fixed"
+	// ModeRotate returns a random entry, weighted by browser share, on
+	// every call.
+	ModeRotate Mode = "rotate"
+	// ModePerSite deterministically hashes the site name so repeated
+	// calls for the same site keep returning the same entry, while
+	// different sites still get spread across the pool.
+	ModePerSite Mode = "per-site"
+)
+
+// DefaultUserAgent is used whenever the weighted pool can't be built
+// (offline, cache unwritable, caniuse unreachable) and no fixed UA was
+// supplied.
+const DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+
+const caniuseDataURL = "https://raw.githubusercontent.com/Fyrd/caniuse/main/fulldata-json/data-2.0.json"
+
+// CacheTTL is how long a fetched pool is considered fresh.
+const CacheTTL = 24 * time.Hour
+
+// Entry is one User-Agent string paired with the relative weight it
+// should be picked with.
+type Entry struct {
+	UserAgent string  `json:"userAgent"`
+	Weight    float64 `json:"weight"`
+}
+
+type cacheFile struct {
+	FetchedAt time.Time `json:"fetchedAt"`
+	Pool      []Entry   `json:"pool"`
+}
+
+// Provider hands out User-Agent strings for outgoing HTTP requests and
+// Chrome screenshots according to its Mode.
+type Provider struct {
+	mode   Mode
+	fixed  string
+	pool   []Entry
+	totalW float64
+	rnd    *rand.Rand
+}
+
+// NewProvider builds a Provider for mode. fixed is only used in
+// ModeFixed. cachePath is where the browser-share-weighted pool is
+// cached between runs; it is read (and refreshed if stale) for
+// ModeRotate and ModePerSite.
+func NewProvider(mode Mode, fixed, cachePath string) (*Provider, error) {
+	p := &Provider{mode: mode, fixed: fixed, rnd: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+	if mode == ModeFixed {
+		return p, nil
+	}
+
+	pool, err := loadPool(cachePath)
+	if err != nil {
+		// Fall back to a single realistic default rather than failing
+		// the whole scan over a missing UA pool.
+		pool = []Entry{{UserAgent: DefaultUserAgent, Weight: 1}}
+	}
+
+	p.pool = pool
+	for _, e := range pool {
+		p.totalW += e.Weight
+	}
+
+	return p, nil
+}
+
+// UserAgent returns a User-Agent string appropriate for site according
+// to the Provider's Mode.
+func (p *Provider) UserAgent(site string) string {
+	switch p.mode {
+	case ModeFixed:
+		if p.fixed != "" {
+			return p.fixed
+		}
+		return DefaultUserAgent
+	case ModePerSite:
+		return p.pick(siteFraction(site))
+	default: // ModeRotate
+		return p.pick(p.rnd.Float64())
+	}
+}
+
+func (p *Provider) pick(fraction float64) string {
+	if len(p.pool) == 0 || p.totalW <= 0 {
+		return DefaultUserAgent
+	}
+
+	target := fraction * p.totalW
+	var cumulative float64
+	for _, e := range p.pool {
+		cumulative += e.Weight
+		if target <= cumulative {
+			return e.UserAgent
+		}
+	}
+
+	return p.pool[len(p.pool)-1].UserAgent
+}
+
+// siteFraction deterministically maps a site name to a value in [0, 1)
+// so the same site always lands on the same pool entry.
+func siteFraction(site string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(site))
+	return float64(h.Sum64()%1_000_000) / 1_000_000
+}
+
+func loadPool(cachePath string) ([]Entry, error) {
+	if cachePath != "" {
+		if cached, ok := readCache(cachePath); ok {
+			return cached, nil
+		}
+	}
+
+	pool, err := fetchPool()
+	if err != nil {
+		return nil, err
+	}
+
+	if cachePath != "" {
+		writeCache(cachePath, pool)
+	}
+
+	return pool, nil
+}
+
+func readCache(cachePath string) ([]Entry, bool) {
+	data, err := ioutil.ReadFile(cachePath)
+	if err != nil {
+		return nil, false
+	}
+
+	var cf cacheFile
+	if err := json.Unmarshal(data, &cf); err != nil {
+		return nil, false
+	}
+
+	if time.Since(cf.FetchedAt) > CacheTTL {
+		return nil, false
+	}
+
+	return cf.Pool, len(cf.Pool) > 0
+}
+
+func writeCache(cachePath string, pool []Entry) error {
+	cf := cacheFile{FetchedAt: time.Now(), Pool: pool}
+
+	data, err := json.Marshal(cf)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(cachePath, data, 0644)
+}
+
+// caniuseAgents is the slice of the caniuse fulldata-json schema we
+// actually need: each browser's per-version global usage share.
+type caniuseAgents struct {
+	Agents map[string]struct {
+		UsageGlobal map[string]float64 `json:"usage_global"`
+	} `json:"agents"`
+}
+
+// browserOS pairs a caniuse browser key with the UA template family and
+// the OS strings it's realistically seen on.
+var browserOS = map[string][]string{
+	"chrome":  {"Windows NT 10.0; Win64; x64", "Macintosh; Intel Mac OS X 10_15_7", "X11; Linux x86_64"},
+	"firefox": {"Windows NT 10.0; Win64; x64", "Macintosh; Intel Mac OS X 10.15", "X11; Linux x86_64"},
+	"edge":    {"Windows NT 10.0; Win64; x64"},
+	"safari":  {"Macintosh; Intel Mac OS X 10_15_7"},
+}
+
+// fetchPool downloads caniuse's browser-share data and turns the top
+// versions of Chrome, Firefox, Safari and Edge into weighted, realistic
+// UA strings across their common operating systems.
+func fetchPool() ([]Entry, error) {
+	resp, err := http.Get(caniuseDataURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var data caniuseAgents
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+
+	var pool []Entry
+	for browser, oses := range browserOS {
+		agent, ok := data.Agents[browser]
+		if !ok {
+			continue
+		}
+
+		for version, share := range topVersions(agent.UsageGlobal, 3) {
+			for _, os := range oses {
+				ua := formatUserAgent(browser, version, os)
+				if ua == "" {
+					continue
+				}
+				pool = append(pool, Entry{UserAgent: ua, Weight: share})
+			}
+		}
+	}
+
+	if len(pool) == 0 {
+		return nil, errNoBrowsers
+	}
+
+	return pool, nil
+}
+
+var errNoBrowsers = errors.New("useragent: no known browsers found in caniuse data")
+
+// topVersions returns, as a map of version -> usage share, the n
+// versions with the highest global usage.
+func topVersions(usage map[string]float64, n int) map[string]float64 {
+	type versionShare struct {
+		version string
+		share   float64
+	}
+
+	all := make([]versionShare, 0, len(usage))
+	for v, s := range usage {
+		all = append(all, versionShare{v, s})
+	}
+
+	for i := 0; i < len(all); i++ {
+		for j := i + 1; j < len(all); j++ {
+			if all[j].share > all[i].share {
+				all[i], all[j] = all[j], all[i]
+			}
+		}
+	}
+
+	if len(all) > n {
+		all = all[:n]
+	}
+
+	top := make(map[string]float64, len(all))
+	for _, vs := range all {
+		top[vs.version] = vs.share
+	}
+
+	return top
+}
+
+func formatUserAgent(browser, version, osString string) string {
+	switch browser {
+	case "chrome":
+		return "Mozilla/5.0 (" + osString + ") AppleWebKit/537.36 (KHTML, like Gecko) Chrome/" + version + " Safari/537.36"
+	case "edge":
+		return "Mozilla/5.0 (" + osString + ") AppleWebKit/537.36 (KHTML, like Gecko) Chrome/" + version + " Safari/537.36 Edg/" + version
+	case "firefox":
+		return "Mozilla/5.0 (" + osString + "; rv:" + version + ") Gecko/20100101 Firefox/" + version
+	case "safari":
+		return "Mozilla/5.0 (" + osString + ") AppleWebKit/605.1.15 (KHTML, like Gecko) Version/" + version + " Safari/605.1.15"
+	default:
+		return ""
+	}
+}